@@ -2,23 +2,34 @@ package main
 
 import (
 	"bufio"
-	"github.com/rsmohamad/comp4321/retrieval"
+	"context"
+	"comp4321/retrieval"
 	"fmt"
 	"os"
 )
 
 func main() {
+	engine, err := retrieval.NewEngine(retrieval.Config{Backend: retrieval.BackendBolt, DBPath: "index.db"})
+	if err != nil {
+		panic(err)
+	}
+	defer engine.Close()
+
+	ctx := context.Background()
 	for {
 		reader := bufio.NewReader(os.Stdin)
 		fmt.Print("Enter search term: ")
 		query, _ := reader.ReadString('\n')
 
-		se := retrieval.NewSearchEngine("index.db")
-		defer se.Close()
+		resp, err := engine.Search(ctx, retrieval.SearchOptions{Keyword: query, Highlight: true})
+		if err != nil {
+			fmt.Println("search error:", err)
+			continue
+		}
 
-		results := se.RetrieveVSpace(query)
-		for _, doc := range results {
-			fmt.Println(doc.Title, doc.Score)
+		fmt.Printf("%d results (hosts: %v)\n", resp.TotalHits, resp.Facets.ByHost)
+		for _, hit := range resp.Hits {
+			fmt.Println(hit.Doc.Title, hit.Score)
 		}
 	}
 }
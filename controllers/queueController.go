@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"comp4321/indexqueue"
+)
+
+var queue *indexqueue.Queue
+
+func queueStatusHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(queue.Status())
+}
+
+// LoadQueue registers /admin/queue, reporting the indexqueue's pending
+// depth and worker count for operators to watch throughput.
+func LoadQueue(q *indexqueue.Queue) {
+	queue = q
+	http.HandleFunc("/admin/queue", queueStatusHandler)
+}
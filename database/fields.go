@@ -0,0 +1,59 @@
+package database
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Field identifies one of the field-scoped inverted/forward/term-weight
+// bucket triples that Indexer indexes a document into. Splitting title,
+// body, anchor, and URL text into their own buckets lets retrieval compute
+// a per-field cosine and apply its own boost instead of folding every term
+// into one undifferentiated p.Words map.
+//
+// MaxTf is a dedicated flat bucket, separate from Forward: Forward's keys
+// hold a nested per-page bucket (pageId -> wordId -> tf), so a plain Put on
+// the same pageId key for maxTf would collide with that nested bucket.
+type Field struct {
+	Name     string
+	Inverted int
+	Forward  int
+	Weights  int
+	MaxTf    int
+}
+
+var (
+	FieldBody   = Field{"body", InvertedTable, BodyForwardTable, TermWeightTable, BodyMaxTfTable}
+	FieldTitle  = Field{"title", TitleInvertedTable, TitleForwardTable, TitleTermWeightTable, TitleMaxTfTable}
+	FieldAnchor = Field{"anchor", AnchorInvertedTable, AnchorForwardTable, AnchorTermWeightTable, AnchorMaxTfTable}
+	FieldUrl    = Field{"url", UrlInvertedTable, UrlForwardTable, UrlTermWeightTable, UrlMaxTfTable}
+)
+
+// Fields lists every field UpdateOrAddPage indexes into, in a fixed order
+// so FlushInverted and UpdateTermWeights iterate deterministically.
+var Fields = []Field{FieldBody, FieldTitle, FieldAnchor, FieldUrl}
+
+// tokenizeField splits free text into a tf map shaped like
+// models.Document.Words, so title and anchor text can be indexed the same
+// way body text already is.
+func tokenizeField(text string) (tf map[string]int, maxTf int) {
+	tf = make(map[string]int)
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		tf[word]++
+		if tf[word] > maxTf {
+			maxTf = tf[word]
+		}
+	}
+	return
+}
+
+// tokenizeURLPath splits a URL's path into words, treating '/', '-', '_',
+// and '.' as separators, so e.g. "/comp4321/hong-kong.html" indexes as
+// "comp4321", "hong", "kong", "html".
+func tokenizeURLPath(rawUrl string) (tf map[string]int, maxTf int) {
+	if u, err := url.Parse(rawUrl); err == nil {
+		rawUrl = u.Path
+	}
+	replacer := strings.NewReplacer("/", " ", "-", " ", "_", " ", ".", " ")
+	return tokenizeField(replacer.Replace(rawUrl))
+}
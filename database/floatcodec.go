@@ -0,0 +1,22 @@
+package database
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// float64ToByte encodes a float64 score (term weight norm, PageRank, ...)
+// for storage as a bolt value, mirroring uint64ToByte/intToByte.
+func float64ToByte(f float64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, math.Float64bits(f))
+	return b
+}
+
+// byteToFloat64 decodes a value written by float64ToByte.
+func byteToFloat64(b []byte) float64 {
+	if b == nil {
+		return 0
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(b))
+}
@@ -1,12 +1,21 @@
 package database
 
 import (
-	"comp4321/models"
 	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
 	"sync"
+
+	"comp4321/models"
+
 	"github.com/boltdb/bolt"
-	"sort"
-	"fmt"
+)
+
+const (
+	dampingFactor  = 0.85
+	maxPRIterations = 100
+	prConvergence  = 1e-6
 )
 
 // The Indexer object abstracts away data structure manipulations
@@ -16,9 +25,10 @@ import (
 type Indexer struct {
 	db *bolt.DB
 
-	// Temporarily hold inverted index in memory
-	tempInverted map[uint64]map[uint64]bool
-	wordIdList   []uint64
+	// Temporarily hold each field's inverted index in memory, keyed by the
+	// field's InvertedTable id.
+	tempInverted map[int]map[uint64]map[uint64]bool
+	wordIdList   map[int][]uint64
 	sync.Mutex
 }
 
@@ -38,6 +48,10 @@ func LoadIndexer(filename string) (*Indexer, error) {
 		}
 		return nil
 	})
+
+	if err := migrate(indexer.db); err != nil {
+		return nil, err
+	}
 	return &indexer, nil
 }
 
@@ -50,6 +64,7 @@ func (i *Indexer) DropAll() {
 		}
 		return nil
 	})
+	setVersion(i.db, CurrentVersion)
 }
 
 // Generic id retriever from mapping table
@@ -105,54 +120,66 @@ func (i *Indexer) updateInverted(word string, pageId []byte, tablename int) {
 	// Critical section - access shared map and slice
 	i.Lock()
 	if i.tempInverted == nil {
-		i.tempInverted = make(map[uint64]map[uint64]bool)
+		i.tempInverted = make(map[int]map[uint64]map[uint64]bool)
+		i.wordIdList = make(map[int][]uint64)
+	}
+
+	fieldPostings := i.tempInverted[tablename]
+	if fieldPostings == nil {
+		fieldPostings = make(map[uint64]map[uint64]bool)
+		i.tempInverted[tablename] = fieldPostings
 	}
 
-	postingList := i.tempInverted[wordIdUint64]
+	postingList := fieldPostings[wordIdUint64]
 	if postingList == nil {
 		postingList = make(map[uint64]bool)
-		i.wordIdList = append(i.wordIdList, wordIdUint64)
+		i.wordIdList[tablename] = append(i.wordIdList[tablename], wordIdUint64)
 	}
 
 	postingList[pageIdUint64] = true
-	i.tempInverted[wordIdUint64] = postingList
+	fieldPostings[wordIdUint64] = postingList
 	i.Unlock()
 	// Non critical section
 }
 
+// FlushInverted merges the in-memory posting lists built up by
+// updateInverted into each field's inverted index. Each word's existing
+// posting list is read and merged with the new docIds in one
+// read-modify-write, all within a single transaction per field, rather than
+// spawning a goroutine per word.
 func (i *Indexer) FlushInverted() {
-	wordIdList := i.wordIdList
+	for tablename, wordIdList := range i.wordIdList {
+		i.flushField(tablename, wordIdList)
+	}
+}
 
+func (i *Indexer) flushField(tablename int, wordIdList []uint64) {
 	// Sort slices for sequential writes
-	sort.Slice(wordIdList, func(i, j int) bool {
-		return wordIdList[i] < wordIdList[j]
+	sort.Slice(wordIdList, func(a, b int) bool {
+		return wordIdList[a] < wordIdList[b]
 	})
 
 	i.db.Update(func(tx *bolt.Tx) error {
-		inverted := tx.Bucket(intToByte(InvertedTable))
+		inverted := tx.Bucket(intToByte(tablename))
 		inverted.FillPercent = 1
-		return nil
-	})
 
-	var wg sync.WaitGroup
-	wg.Add(len(wordIdList))
-	for index, id := range wordIdList {
-		idBytes := uint64ToByte(id)
-		fmt.Printf("Merging word %d out of %d | WordID: ", index+1, len(wordIdList))
-		fmt.Println(idBytes)
-		go i.db.Batch(func(tx *bolt.Tx) error {
-			inverted := tx.Bucket(intToByte(InvertedTable))
-			wordSet, _ := inverted.CreateBucketIfNotExists(idBytes)
-			postingList := i.tempInverted[id]
-			for docId, _ := range postingList {
-				wordSet.Put(uint64ToByte(docId), []byte{1})
+		for index, id := range wordIdList {
+			idBytes := uint64ToByte(id)
+			fmt.Printf("Merging word %d out of %d | Field: %d | WordID: ", index+1, len(wordIdList), tablename)
+			fmt.Println(idBytes)
+
+			wordSet, err := inverted.CreateBucketIfNotExists(idBytes)
+			if err != nil {
+				return err
 			}
 
-			wg.Done()
-			return nil
-		})
-	}
-	wg.Wait()
+			postingList := i.tempInverted[tablename][id]
+			for docId := range postingList {
+				wordSet.Put(uint64ToByte(docId), []byte{1})
+			}
+		}
+		return nil
+	})
 }
 
 func (i *Indexer) updateForward(word string, pageId []byte, tf int, tablename int) {
@@ -176,41 +203,66 @@ func (i *Indexer) ContainsUrl(url string) (present bool) {
 	return
 }
 
-func (i *Indexer) setMaxTf(pageId []byte, maxTf int){
+// setMaxTf stores maxTf in field's dedicated MaxTf bucket, a flat
+// pageId -> maxTf mapping. This must not share a bucket with field.Forward:
+// Forward's pageId key holds a nested bucket (wordId -> tf), and bolt
+// returns ErrIncompatibleValue from a Put on a key that already holds a
+// bucket - a Batch closure that ignores that error silently drops maxTf.
+func (i *Indexer) setMaxTf(pageId []byte, field Field, maxTf int) {
 	i.db.Batch(func(tx *bolt.Tx) error {
-		fwTable := tx.Bucket(intToByte(ForwardTable))
-		fwTable.Put(pageId, intToByte(maxTf))
-		return nil
+		maxTfTable := tx.Bucket(intToByte(field.MaxTf))
+		return maxTfTable.Put(pageId, intToByte(maxTf))
 	})
 }
 
-func (i *Indexer) getMaxTf(pageId []byte) (maxTf int) {
+func (i *Indexer) getMaxTf(pageId []byte, field Field) (maxTf int) {
 	i.db.View(func(tx *bolt.Tx) error {
-		fwTable := tx.Bucket(intToByte(ForwardTable))
-		maxTf = byteToInt(fwTable.Get(pageId))
+		maxTfTable := tx.Bucket(intToByte(field.MaxTf))
+		maxTf = byteToInt(maxTfTable.Get(pageId))
 		return nil
 	})
 	return
 }
 
-// Insert page into the database.
-// This will update all mapping tables and indexes.
-func (i *Indexer) UpdateOrAddPage(p *models.Document) {
-	pageId := i.getOrCreatePageId(p.Uri)
+// indexField updates the inverted and forward indexes for one field's tf
+// map, the same read-modify-write pattern UpdateOrAddPage always used for
+// body text, just parameterized by field so title/anchor/url text get their
+// own buckets.
+func (i *Indexer) indexField(pageId []byte, words map[string]int, maxTf int, field Field) {
 	var wg sync.WaitGroup
-	wg.Add(2 * len(p.Words))
-	for word, tf := range p.Words {
-		go func() {
-			i.updateInverted(word, pageId, InvertedTable)
+	wg.Add(2 * len(words))
+	for word, tf := range words {
+		go func(word string) {
+			i.updateInverted(word, pageId, field.Inverted)
 			wg.Done()
-		}()
-		go func() {
-			i.updateForward(word, pageId, tf, ForwardTable)
+		}(word)
+		go func(word string, tf int) {
+			i.updateForward(word, pageId, tf, field.Forward)
 			wg.Done()
-		}()
+		}(word, tf)
 	}
 	wg.Wait()
-	i.setMaxTf(pageId, p.MaxTf)
+	i.setMaxTf(pageId, field, maxTf)
+}
+
+// Insert page into the database.
+// This will update all mapping tables and indexes: body, title, anchor
+// text, and the tokenized URL path each get their own inverted/forward
+// buckets so retrieval can boost title/anchor/url matches independently.
+func (i *Indexer) UpdateOrAddPage(p *models.Document) {
+	pageId := i.getOrCreatePageId(p.Uri)
+
+	i.indexField(pageId, p.Words, p.MaxTf, FieldBody)
+
+	titleTf, titleMaxTf := tokenizeField(p.Title)
+	i.indexField(pageId, titleTf, titleMaxTf, FieldTitle)
+
+	anchorTf, anchorMaxTf := tokenizeField(p.AnchorText)
+	i.indexField(pageId, anchorTf, anchorMaxTf, FieldAnchor)
+
+	urlTf, urlMaxTf := tokenizeURLPath(p.Uri)
+	i.indexField(pageId, urlTf, urlMaxTf, FieldUrl)
+
 	i.db.Batch(func(tx *bolt.Tx) error {
 		documents := tx.Bucket(intToByte(PageInfo))
 		encoded, _ := json.Marshal(p)
@@ -219,22 +271,220 @@ func (i *Indexer) UpdateOrAddPage(p *models.Document) {
 	})
 }
 
-// TODO
-// Update adj list structure
-func (i *Indexer) UpdateAdjList() {
+// Delete removes a page from every field's forward and inverted index, and
+// from PageInfo, so it no longer surfaces in search results.
+func (i *Indexer) Delete(pageId uint64) {
+	idBytes := uint64ToByte(pageId)
+
+	i.db.Update(func(tx *bolt.Tx) error {
+		for _, field := range Fields {
+			forward := tx.Bucket(intToByte(field.Forward))
+			inverted := tx.Bucket(intToByte(field.Inverted))
+
+			pageBucket := forward.Bucket(idBytes)
+			if pageBucket == nil {
+				continue
+			}
+			pageBucket.ForEach(func(wordId, _ []byte) error {
+				if wordSet := inverted.Bucket(wordId); wordSet != nil {
+					wordSet.Delete(idBytes)
+				}
+				return nil
+			})
+			forward.DeleteBucket(idBytes)
+		}
+
+		tx.Bucket(intToByte(PageInfo)).Delete(idBytes)
+		return nil
+	})
+}
+
+// UpdateAdjList persists the parent->child and child->parent adjacency
+// implied by p.Links, keyed by pageId, so UpdatePageRank can walk out/in
+// edges without re-parsing documents.
+func (i *Indexer) UpdateAdjList(p *models.Document) {
+	pageId := i.getOrCreatePageId(p.Uri)
+
+	childIds := make([][]byte, len(p.Links))
+	for idx, link := range p.Links {
+		childIds[idx] = i.getOrCreatePageId(link)
+	}
+
+	i.db.Update(func(tx *bolt.Tx) error {
+		parentToChild := tx.Bucket(intToByte(AdjListOut))
+		childToParent := tx.Bucket(intToByte(AdjListIn))
+
+		children, err := parentToChild.CreateBucketIfNotExists(pageId)
+		if err != nil {
+			return err
+		}
+
+		for _, childId := range childIds {
+			children.Put(childId, []byte{1})
 
+			parents, err := childToParent.CreateBucketIfNotExists(childId)
+			if err != nil {
+				return err
+			}
+			parents.Put(pageId, []byte{1})
+		}
+		return nil
+	})
 }
 
-// TODO
-// Update term weights
+// UpdateTermWeights precomputes each document's tf-idf vector norm
+// (sqrt(sum((tf/maxTf * log2(N/df))^2))) into a dedicated bucket per field,
+// keyed by pageId, so retrieval's weighted cosine becomes an O(posting)
+// computation with one bucket Get per candidate per field instead of
+// recomputing idf per query.
 func (i *Indexer) UpdateTermWeights() {
+	for _, field := range Fields {
+		i.updateTermWeightsForField(field)
+	}
+}
+
+func (i *Indexer) updateTermWeightsForField(field Field) {
+	i.db.Update(func(tx *bolt.Tx) error {
+		forward := tx.Bucket(intToByte(field.Forward))
+		inverted := tx.Bucket(intToByte(field.Inverted))
+		weights, err := tx.CreateBucketIfNotExists(intToByte(field.Weights))
+		if err != nil {
+			return err
+		}
 
+		// N must be the same corpus size GetIdf uses at query time, or the
+		// idf baked into this norm disagrees with the idf applied to the
+		// query and the cosine is computed against mismatched scales.
+		n := float64(tx.Bucket(intToByte(PageIdToUrl)).Stats().KeyN)
+
+		return forward.ForEach(func(pageId, v []byte) error {
+			pageBucket := forward.Bucket(pageId)
+			if pageBucket == nil {
+				return nil
+			}
+
+			maxTf := i.getMaxTf(pageId, field)
+			if maxTf == 0 {
+				return nil
+			}
+
+			var sumSquares float64
+			pageBucket.ForEach(func(wordId, tfBytes []byte) error {
+				df := 0
+				if wordSet := inverted.Bucket(wordId); wordSet != nil {
+					df = wordSet.Stats().KeyN
+				}
+				if df == 0 {
+					return nil
+				}
+
+				tf := byteToInt(tfBytes)
+				weight := (float64(tf) / float64(maxTf)) * math.Log2(n/float64(df))
+				sumSquares += weight * weight
+				return nil
+			})
+
+			weights.Put(pageId, float64ToByte(math.Sqrt(sumSquares)))
+			return nil
+		})
+	})
 }
 
-// TODO
-// Update page rank
+// UpdatePageRank runs power iteration over the adjacency graph built by
+// UpdateAdjList: PR(p) = (1-d)/N + d*sum(PR(q)/OutDeg(q)), initialized
+// uniformly, until the largest per-page delta drops below prConvergence or
+// maxPRIterations is reached. Dangling nodes (no outlinks) redistribute
+// their mass uniformly across every page each iteration.
 func (i *Indexer) UpdatePageRank() {
+	var pageIds []uint64
+	outDeg := make(map[uint64]int)
+	outLinks := make(map[uint64][]uint64)
+
+	i.db.View(func(tx *bolt.Tx) error {
+		pages := tx.Bucket(intToByte(PageIdToUrl))
+		pages.ForEach(func(k, _ []byte) error {
+			pageIds = append(pageIds, byteToUint64(k))
+			return nil
+		})
+
+		adjOut := tx.Bucket(intToByte(AdjListOut))
+		for _, id := range pageIds {
+			children := adjOut.Bucket(uint64ToByte(id))
+			if children == nil {
+				continue
+			}
 
+			var links []uint64
+			children.ForEach(func(childId, _ []byte) error {
+				links = append(links, byteToUint64(childId))
+				return nil
+			})
+			outLinks[id] = links
+			outDeg[id] = len(links)
+		}
+		return nil
+	})
+
+	n := float64(len(pageIds))
+	if n == 0 {
+		return
+	}
+
+	rank := make(map[uint64]float64, len(pageIds))
+	for _, id := range pageIds {
+		rank[id] = 1.0 / n
+	}
+
+	for iter := 0; iter < maxPRIterations; iter++ {
+		next := make(map[uint64]float64, len(pageIds))
+		for _, id := range pageIds {
+			next[id] = (1 - dampingFactor) / n
+		}
+
+		var danglingMass float64
+		for _, id := range pageIds {
+			if outDeg[id] == 0 {
+				danglingMass += rank[id]
+			}
+		}
+		danglingShare := dampingFactor * danglingMass / n
+
+		for _, id := range pageIds {
+			next[id] += danglingShare
+
+			deg := outDeg[id]
+			if deg == 0 {
+				continue
+			}
+			share := dampingFactor * rank[id] / float64(deg)
+			for _, child := range outLinks[id] {
+				next[child] += share
+			}
+		}
+
+		maxDelta := 0.0
+		for _, id := range pageIds {
+			if delta := math.Abs(next[id] - rank[id]); delta > maxDelta {
+				maxDelta = delta
+			}
+		}
+
+		rank = next
+		if maxDelta < prConvergence {
+			break
+		}
+	}
+
+	i.db.Update(func(tx *bolt.Tx) error {
+		pr, err := tx.CreateBucketIfNotExists(intToByte(PageRankTable))
+		if err != nil {
+			return err
+		}
+		for id, score := range rank {
+			pr.Put(uint64ToByte(id), float64ToByte(score))
+		}
+		return nil
+	})
 }
 
 func (i *Indexer) Close() {
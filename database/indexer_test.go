@@ -0,0 +1,55 @@
+package database
+
+import (
+	"os"
+	"testing"
+
+	"comp4321/models"
+)
+
+// TestUpdateTermWeightsNonZero guards against the maxTf bucket collision:
+// setMaxTf used to Put a flat value on the same pageId key that Forward's
+// nested per-page bucket already occupied, so maxTf was silently dropped
+// and every document's term weight norm came out 0.
+func TestUpdateTermWeightsNonZero(t *testing.T) {
+	f, err := os.CreateTemp("", "index-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	indexer, err := LoadIndexer(f.Name())
+	if err != nil {
+		t.Fatalf("LoadIndexer: %v", err)
+	}
+	defer indexer.Close()
+
+	doc := &models.Document{
+		Uri:   "http://example.com/a",
+		Title: "hello world",
+		Words: map[string]int{"hello": 2, "world": 1},
+		MaxTf: 2,
+	}
+	indexer.UpdateOrAddPage(doc)
+	// A second page keeps N above 1: with a single page every term's idf is
+	// log2(1/1) = 0, so the norm would be 0 even with maxTf stored correctly.
+	indexer.UpdateOrAddPage(&models.Document{
+		Uri:   "http://example.com/b",
+		Words: map[string]int{"other": 1},
+		MaxTf: 1,
+	})
+	indexer.FlushInverted()
+	indexer.UpdateTermWeights()
+
+	reader, err := indexer.Reader()
+	if err != nil {
+		t.Fatalf("Reader: %v", err)
+	}
+	defer reader.Close()
+
+	pageId := byteToUint64(indexer.getOrCreatePageId(doc.Uri))
+	if norm := reader.GetTermWeightNorm(pageId, FieldBody); norm == 0 {
+		t.Fatalf("GetTermWeightNorm(body) = 0, want nonzero after indexing %q", doc.Uri)
+	}
+}
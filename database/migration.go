@@ -0,0 +1,181 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/boltdb/bolt"
+)
+
+// CurrentVersion is the index schema version this build expects. Bump it
+// and append a migration func whenever NumTable or a bucket layout changes,
+// so existing index.db files are upgraded instead of silently corrupted.
+const CurrentVersion byte = 5
+
+var metaBucket = []byte("meta")
+var versionKey = []byte("version")
+
+// migrations run in order on open; migrations[v] takes the db from version
+// v to v+1. Each step must be idempotent: it can be re-applied if the
+// version bump after it didn't commit.
+var migrations = []func(*bolt.DB) error{
+	migrateV0ToV1,
+	migrateV1ToV2,
+	migrateV2ToV3,
+	migrateV3ToV4,
+	migrateV4ToV5,
+}
+
+// migrateV0ToV1 adds the position-index bucket used by hasPhrase.
+func migrateV0ToV1(db *bolt.DB) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(intToByte(PositionIndex))
+		return err
+	})
+}
+
+// migrateV1ToV2 adds the PageRank bucket populated by UpdatePageRank.
+func migrateV1ToV2(db *bolt.DB) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(intToByte(PageRankTable))
+		return err
+	})
+}
+
+// migrateV2ToV3 splits the legacy single ForwardTable into per-field
+// forward buckets. Body is the only field that existed before the split,
+// so its postings must move rather than be dropped: every pageId's nested
+// word->tf bucket under the legacy table is copied into BodyForwardTable.
+// Title's forward bucket has no legacy data to carry over - the title
+// field didn't exist until this version - so it's simply created.
+func migrateV2ToV3(db *bolt.DB) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(intToByte(TitleForwardTable)); err != nil {
+			return err
+		}
+
+		bodyForward, err := tx.CreateBucketIfNotExists(intToByte(BodyForwardTable))
+		if err != nil {
+			return err
+		}
+
+		legacy := tx.Bucket(intToByte(ForwardTable))
+		if legacy == nil {
+			return nil
+		}
+		return legacy.ForEach(func(pageId, v []byte) error {
+			legacyPage := legacy.Bucket(pageId)
+			if legacyPage == nil {
+				// Not a nested bucket: a stray flat value left over from
+				// the maxTf bucket-collision bug, nothing to migrate.
+				return nil
+			}
+			bodyPage, err := bodyForward.CreateBucketIfNotExists(pageId)
+			if err != nil {
+				return err
+			}
+			return legacyPage.ForEach(func(wordId, tf []byte) error {
+				return bodyPage.Put(wordId, tf)
+			})
+		})
+	})
+}
+
+// migrateV3ToV4 adds the remaining per-field buckets needed to split
+// indexing into title, anchor, and URL fields alongside body: title's
+// inverted and term-weight buckets (its forward bucket already exists from
+// migrateV2ToV3), and the full inverted/forward/term-weight triple for the
+// new anchor and URL fields.
+func migrateV3ToV4(db *bolt.DB) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		for _, table := range []int{
+			TitleInvertedTable, TitleTermWeightTable,
+			AnchorInvertedTable, AnchorForwardTable, AnchorTermWeightTable,
+			UrlInvertedTable, UrlForwardTable, UrlTermWeightTable,
+		} {
+			if _, err := tx.CreateBucketIfNotExists(intToByte(table)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateV4ToV5 gives each field its own MaxTf bucket (see Field.MaxTf),
+// separate from Forward, and backfills it from each page's already-indexed
+// forward postings. Forward's maxTf used to be stored as a flat Put on the
+// same pageId key that also held Forward's nested per-page bucket, so it
+// was silently dropped on every write; recomputing it here from the real
+// per-word tf entries repairs every page indexed before this version,
+// rather than leaving the bucket empty until the next reindex.
+func migrateV4ToV5(db *bolt.DB) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		for _, field := range Fields {
+			maxTfTable, err := tx.CreateBucketIfNotExists(intToByte(field.MaxTf))
+			if err != nil {
+				return err
+			}
+
+			forward := tx.Bucket(intToByte(field.Forward))
+			if err := forward.ForEach(func(pageId, v []byte) error {
+				pageBucket := forward.Bucket(pageId)
+				if pageBucket == nil {
+					return nil
+				}
+
+				maxTf := 0
+				pageBucket.ForEach(func(_, tfBytes []byte) error {
+					if tf := byteToInt(tfBytes); tf > maxTf {
+						maxTf = tf
+					}
+					return nil
+				})
+				if maxTf == 0 {
+					return nil
+				}
+				return maxTfTable.Put(pageId, intToByte(maxTf))
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrate brings db up to CurrentVersion, persisting and logging progress
+// after each step so a crash mid-migration resumes rather than rerunning
+// from v0.
+func migrate(db *bolt.DB) error {
+	var version byte
+	db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		if v := b.Get(versionKey); v != nil {
+			version = v[0]
+		}
+		return nil
+	})
+
+	for version < CurrentVersion {
+		step := migrations[version]
+		fmt.Printf("index.db: migrating v%d -> v%d\n", version, version+1)
+
+		if err := step(db); err != nil {
+			return fmt.Errorf("migrate v%d->v%d: %w", version, version+1, err)
+		}
+
+		version++
+		if err := setVersion(db, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setVersion(db *bolt.DB, version byte) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(metaBucket)
+		return b.Put(versionKey, []byte{version})
+	})
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"os"
+	"testing"
+
+	"github.com/boltdb/bolt"
+)
+
+// TestMigrateV2ToV3CarriesLegacyPostings seeds a v2-shaped ForwardTable
+// (the single pre-split forward bucket body text used before per-field
+// buckets existed) and checks migrateV2ToV3 copies its postings into
+// BodyForwardTable rather than dropping them.
+func TestMigrateV2ToV3CarriesLegacyPostings(t *testing.T) {
+	f, err := os.CreateTemp("", "index-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	db, err := bolt.Open(f.Name(), 0666, nil)
+	if err != nil {
+		t.Fatalf("bolt.Open: %v", err)
+	}
+	defer db.Close()
+
+	pageId := uint64ToByte(1)
+	wordId := uint64ToByte(2)
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for i := 0; i < NumTable; i++ {
+			if _, err := tx.CreateBucketIfNotExists(intToByte(i)); err != nil {
+				return err
+			}
+		}
+		legacy := tx.Bucket(intToByte(ForwardTable))
+		page, err := legacy.CreateBucketIfNotExists(pageId)
+		if err != nil {
+			return err
+		}
+		return page.Put(wordId, intToByte(3))
+	}); err != nil {
+		t.Fatalf("seed v2 ForwardTable: %v", err)
+	}
+
+	if err := migrateV2ToV3(db); err != nil {
+		t.Fatalf("migrateV2ToV3: %v", err)
+	}
+
+	db.View(func(tx *bolt.Tx) error {
+		bodyForward := tx.Bucket(intToByte(BodyForwardTable))
+		page := bodyForward.Bucket(pageId)
+		if page == nil {
+			t.Fatal("BodyForwardTable has no bucket for the migrated pageId")
+			return nil
+		}
+		if tf := byteToInt(page.Get(wordId)); tf != 3 {
+			t.Errorf("BodyForwardTable tf = %d, want 3 (carried over from legacy ForwardTable)", tf)
+		}
+		return nil
+	})
+}
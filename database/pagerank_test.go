@@ -0,0 +1,56 @@
+package database
+
+import (
+	"math"
+	"os"
+	"testing"
+
+	"comp4321/models"
+)
+
+// TestUpdatePageRankDanglingNode checks UpdatePageRank's power iteration
+// against a hand-solved fixed point: A and B link to each other, C is a
+// dangling node (no outlinks) whose mass is redistributed uniformly.
+// Solving PR(p) = (1-d)/N + d*sum(PR(q)/OutDeg(q)) for d=0.85, N=3 gives
+// PR(A) = PR(B) = 20/43 and PR(C) = 3/43.
+func TestUpdatePageRankDanglingNode(t *testing.T) {
+	f, err := os.CreateTemp("", "index-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	indexer, err := LoadIndexer(f.Name())
+	if err != nil {
+		t.Fatalf("LoadIndexer: %v", err)
+	}
+	defer indexer.Close()
+
+	indexer.UpdateAdjList(&models.Document{Uri: "http://example.com/a", Links: []string{"http://example.com/b"}})
+	indexer.UpdateAdjList(&models.Document{Uri: "http://example.com/b", Links: []string{"http://example.com/a"}})
+	indexer.UpdateAdjList(&models.Document{Uri: "http://example.com/c"})
+
+	indexer.UpdatePageRank()
+
+	reader, err := indexer.Reader()
+	if err != nil {
+		t.Fatalf("Reader: %v", err)
+	}
+	defer reader.Close()
+
+	pageIdA := byteToUint64(indexer.getOrCreatePageId("http://example.com/a"))
+	pageIdB := byteToUint64(indexer.getOrCreatePageId("http://example.com/b"))
+	pageIdC := byteToUint64(indexer.getOrCreatePageId("http://example.com/c"))
+
+	const tolerance = 1e-4
+	if got, want := reader.GetPageRank(pageIdA), 20.0/43.0; math.Abs(got-want) > tolerance {
+		t.Errorf("PageRank(A) = %v, want %v", got, want)
+	}
+	if got, want := reader.GetPageRank(pageIdB), 20.0/43.0; math.Abs(got-want) > tolerance {
+		t.Errorf("PageRank(B) = %v, want %v", got, want)
+	}
+	if got, want := reader.GetPageRank(pageIdC), 3.0/43.0; math.Abs(got-want) > tolerance {
+		t.Errorf("PageRank(C) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,290 @@
+package database
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+
+	"comp4321/models"
+
+	"github.com/boltdb/bolt"
+)
+
+// IndexReader is a read-only, point-in-time snapshot of the index, wrapping
+// a single bolt.Tx so that queries are unaffected by concurrent
+// UpdateOrAddPage calls. retrieval consumes only this interface rather than
+// opening a fresh Viewer per request.
+type IndexReader struct {
+	tx *bolt.Tx
+}
+
+// Reader opens a consistent snapshot of the index for queries. The caller
+// must Close it when done to release the underlying bolt.Tx.
+func (i *Indexer) Reader() (*IndexReader, error) {
+	tx, err := i.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	return &IndexReader{tx: tx}, nil
+}
+
+// TermFieldReader walks the posting list for one term in one field's
+// inverted bucket, in docId order.
+type TermFieldReader struct {
+	cursor  *bolt.Cursor
+	started bool
+}
+
+// TermFieldReader returns a reader over the posting list for term in field,
+// or nil if the term is unknown or has no postings.
+func (r *IndexReader) TermFieldReader(term string, field int) *TermFieldReader {
+	wordId := r.lookupWordId(term)
+	if wordId == nil {
+		return nil
+	}
+
+	inverted := r.tx.Bucket(intToByte(field))
+	wordSet := inverted.Bucket(wordId)
+	if wordSet == nil {
+		return nil
+	}
+	return &TermFieldReader{cursor: wordSet.Cursor()}
+}
+
+// Next returns the next docId in the posting list, or (0, false) once
+// exhausted.
+func (t *TermFieldReader) Next() (uint64, bool) {
+	var k []byte
+	if !t.started {
+		t.started = true
+		k, _ = t.cursor.First()
+	} else {
+		k, _ = t.cursor.Next()
+	}
+	if k == nil {
+		return 0, false
+	}
+	return byteToUint64(k), true
+}
+
+// DocIDReader walks every known pageId in [start, end), ascending.
+type DocIDReader struct {
+	cursor  *bolt.Cursor
+	start   uint64
+	end     uint64
+	started bool
+	done    bool
+}
+
+// DocIDReader returns a reader over every pageId in [start, end).
+func (r *IndexReader) DocIDReader(start, end uint64) *DocIDReader {
+	pages := r.tx.Bucket(intToByte(PageIdToUrl))
+	return &DocIDReader{cursor: pages.Cursor(), start: start, end: end}
+}
+
+// Next returns the next pageId, or (0, false) once end is reached.
+func (d *DocIDReader) Next() (uint64, bool) {
+	if d.done {
+		return 0, false
+	}
+
+	var k []byte
+	if !d.started {
+		d.started = true
+		k, _ = d.cursor.Seek(uint64ToByte(d.start))
+	} else {
+		k, _ = d.cursor.Next()
+	}
+	if k == nil {
+		d.done = true
+		return 0, false
+	}
+
+	id := byteToUint64(k)
+	if id >= d.end {
+		d.done = true
+		return 0, false
+	}
+	return id, true
+}
+
+// GetPositionIndices returns the positions of term within pageId's document,
+// used by searchPhrase to confirm adjacent bigrams.
+func (r *IndexReader) GetPositionIndices(pageId uint64, term string) []int {
+	wordId := r.lookupWordId(term)
+	if wordId == nil {
+		return nil
+	}
+
+	positions := r.tx.Bucket(intToByte(PositionIndex))
+	pageBucket := positions.Bucket(uint64ToByte(pageId))
+	if pageBucket == nil {
+		return nil
+	}
+
+	raw := pageBucket.Get(wordId)
+	if raw == nil {
+		return nil
+	}
+
+	var rv []int
+	json.Unmarshal(raw, &rv)
+	return rv
+}
+
+// GetDocument fetches and decodes the models.Document stored for pageId in
+// the PageInfo bucket by UpdateOrAddPage.
+func (r *IndexReader) GetDocument(pageId uint64) (*models.Document, error) {
+	documents := r.tx.Bucket(intToByte(PageInfo))
+	raw := documents.Get(uint64ToByte(pageId))
+	if raw == nil {
+		return nil, nil
+	}
+
+	doc := &models.Document{}
+	if err := json.Unmarshal(raw, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// GetDocumentView assembles the summary used to render one search result,
+// including the PageRank computed offline by Indexer.UpdatePageRank so it
+// can be surfaced for debugging.
+func (r *IndexReader) GetDocumentView(pageId uint64) *models.DocumentView {
+	doc, err := r.GetDocument(pageId)
+	if err != nil || doc == nil {
+		return nil
+	}
+	return &models.DocumentView{
+		PageId:   pageId,
+		Title:    doc.Title,
+		Url:      doc.Uri,
+		Host:     doc.Host,
+		PageRank: r.GetPageRank(pageId),
+	}
+}
+
+// GetPageRank returns the PageRank score computed for pageId by
+// Indexer.UpdatePageRank, or 0 if it has not been computed yet.
+func (r *IndexReader) GetPageRank(pageId uint64) float64 {
+	pr := r.tx.Bucket(intToByte(PageRankTable))
+	return byteToFloat64(pr.Get(uint64ToByte(pageId)))
+}
+
+// GetTermWeightNorm returns the precomputed tf-idf vector norm for pageId in
+// the given field, written by Indexer.UpdateTermWeights, or 0 if it has not
+// been computed.
+func (r *IndexReader) GetTermWeightNorm(pageId uint64, field Field) float64 {
+	weights := r.tx.Bucket(intToByte(field.Weights))
+	return byteToFloat64(weights.Get(uint64ToByte(pageId)))
+}
+
+// GetMaxTf returns the document's true per-field maximum term frequency,
+// written into field.MaxTf by Indexer.indexField, or 0 if the field has not
+// been indexed for pageId yet.
+func (r *IndexReader) GetMaxTf(pageId uint64, field Field) int {
+	maxTfTable := r.tx.Bucket(intToByte(field.MaxTf))
+	return byteToInt(maxTfTable.Get(uint64ToByte(pageId)))
+}
+
+// GetTermFrequency returns the raw term frequency of term in pageId's
+// document for the given field, or 0 if the term does not occur there.
+func (r *IndexReader) GetTermFrequency(pageId uint64, term string, field Field) int {
+	wordId := r.lookupWordId(term)
+	if wordId == nil {
+		return 0
+	}
+
+	forward := r.tx.Bucket(intToByte(field.Forward))
+	pageBucket := forward.Bucket(uint64ToByte(pageId))
+	if pageBucket == nil {
+		return 0
+	}
+	return byteToInt(pageBucket.Get(wordId))
+}
+
+// GetIdf returns log2(N/df) for term within field, where N is the number of
+// indexed pages and df is the number of pages term appears in for field.
+func (r *IndexReader) GetIdf(term string, field Field) float64 {
+	wordId := r.lookupWordId(term)
+	if wordId == nil {
+		return 0
+	}
+
+	inverted := r.tx.Bucket(intToByte(field.Inverted))
+	wordSet := inverted.Bucket(wordId)
+	if wordSet == nil {
+		return 0
+	}
+
+	df := wordSet.Stats().KeyN
+	if df == 0 {
+		return 0
+	}
+
+	n := float64(r.tx.Bucket(intToByte(PageIdToUrl)).Stats().KeyN)
+	return math.Log2(n / float64(df))
+}
+
+// TermFrequency pairs a term with an aggregate frequency, returned by
+// TopTermsForField.
+type TermFrequency struct {
+	Term  string
+	Count int
+}
+
+// TopTermsForField aggregates per-word term frequencies across pageIds'
+// forward postings for field and returns the limit most frequent terms, so
+// callers can build faceted "related terms" navigation from what the
+// candidate set actually contains rather than restating the query.
+func (r *IndexReader) TopTermsForField(pageIds []uint64, field Field, limit int) []TermFrequency {
+	forward := r.tx.Bucket(intToByte(field.Forward))
+
+	counts := make(map[uint64]int)
+	for _, pageId := range pageIds {
+		pageBucket := forward.Bucket(uint64ToByte(pageId))
+		if pageBucket == nil {
+			continue
+		}
+		pageBucket.ForEach(func(wordId, tfBytes []byte) error {
+			counts[byteToUint64(wordId)] += byteToInt(tfBytes)
+			return nil
+		})
+	}
+
+	ordered := make([]uint64, 0, len(counts))
+	for wordId := range counts {
+		ordered = append(ordered, wordId)
+	}
+	sort.Slice(ordered, func(a, b int) bool {
+		if counts[ordered[a]] != counts[ordered[b]] {
+			return counts[ordered[a]] > counts[ordered[b]]
+		}
+		return ordered[a] < ordered[b]
+	})
+	if len(ordered) > limit {
+		ordered = ordered[:limit]
+	}
+
+	invWordMap := r.tx.Bucket(intToByte(WordIdToWord))
+	terms := make([]TermFrequency, 0, len(ordered))
+	for _, wordId := range ordered {
+		word := invWordMap.Get(uint64ToByte(wordId))
+		if word == nil {
+			continue
+		}
+		terms = append(terms, TermFrequency{Term: string(word), Count: counts[wordId]})
+	}
+	return terms
+}
+
+func (r *IndexReader) lookupWordId(word string) []byte {
+	forwardMap := r.tx.Bucket(intToByte(WordToWordId))
+	return forwardMap.Get([]byte(word))
+}
+
+// Close releases the underlying bolt.Tx. Safe to call once.
+func (r *IndexReader) Close() error {
+	return r.tx.Rollback()
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"os"
+	"testing"
+
+	"comp4321/models"
+)
+
+// TestReaderIsPointInTimeSnapshot guards IndexReader's core promise: a
+// Reader opened before a write must not observe that write, even after it
+// commits, because it wraps a single bolt.Tx taken at Reader() time rather
+// than reading through to the live db.
+func TestReaderIsPointInTimeSnapshot(t *testing.T) {
+	f, err := os.CreateTemp("", "index-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	indexer, err := LoadIndexer(f.Name())
+	if err != nil {
+		t.Fatalf("LoadIndexer: %v", err)
+	}
+	defer indexer.Close()
+
+	before, err := indexer.Reader()
+	if err != nil {
+		t.Fatalf("Reader: %v", err)
+	}
+	defer before.Close()
+
+	indexer.UpdateOrAddPage(&models.Document{
+		Uri:   "http://example.com/a",
+		Words: map[string]int{"newword": 1},
+		MaxTf: 1,
+	})
+	indexer.FlushInverted()
+
+	after, err := indexer.Reader()
+	if err != nil {
+		t.Fatalf("Reader: %v", err)
+	}
+	defer after.Close()
+
+	if r := before.TermFieldReader("newword", FieldBody.Inverted); r != nil {
+		t.Fatal("snapshot opened before the write saw postings written after it")
+	}
+	if r := after.TermFieldReader("newword", FieldBody.Inverted); r == nil {
+		t.Fatal("snapshot opened after the write did not see its postings")
+	}
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"os"
+	"testing"
+
+	"comp4321/models"
+
+	"github.com/boltdb/bolt"
+)
+
+// TestGetDocumentViewNilOnMissingPageInfo guards the case a crash between
+// UpdateOrAddPage's forward/inverted writes and its PageInfo write can leave
+// behind: a pageId with postings but no PageInfo record. Callers (notably
+// boltEngine.Search) rely on this returning nil rather than a zero-value
+// DocumentView so they can skip the hit instead of dereferencing a document
+// that was never actually written.
+func TestGetDocumentViewNilOnMissingPageInfo(t *testing.T) {
+	f, err := os.CreateTemp("", "index-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	indexer, err := LoadIndexer(f.Name())
+	if err != nil {
+		t.Fatalf("LoadIndexer: %v", err)
+	}
+	defer indexer.Close()
+
+	doc := &models.Document{Uri: "http://example.com/a", Words: map[string]int{"hello": 1}, MaxTf: 1}
+	indexer.UpdateOrAddPage(doc)
+	pageId := byteToUint64(indexer.getOrCreatePageId(doc.Uri))
+
+	// Simulate the non-atomic crash window: postings exist, PageInfo doesn't.
+	indexer.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(intToByte(PageInfo)).Delete(uint64ToByte(pageId))
+	})
+
+	reader, err := indexer.Reader()
+	if err != nil {
+		t.Fatalf("Reader: %v", err)
+	}
+	defer reader.Close()
+
+	if view := reader.GetDocumentView(pageId); view != nil {
+		t.Fatalf("GetDocumentView = %+v, want nil for a pageId with no PageInfo record", view)
+	}
+}
@@ -0,0 +1,243 @@
+// Package indexqueue decouples the crawler from indexing. The crawler
+// enqueues lightweight IndexerMetadata instead of calling a search Engine
+// synchronously for every page, and a pool of workers drains the queue,
+// re-fetching and parsing each page before indexing it.
+package indexqueue
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"comp4321/models"
+	"comp4321/retrieval"
+
+	"github.com/boltdb/bolt"
+)
+
+var pendingBucket = []byte("pending")
+
+// Op identifies what a worker should do with the page named by an
+// IndexerMetadata message.
+type Op int
+
+const (
+	OpAdd Op = iota
+	OpUpdate
+	OpDelete
+)
+
+// IndexerMetadata is the lightweight message the crawler enqueues in place
+// of a full models.Document; a worker turns it back into one via FetchFunc.
+//
+// Version is stored and replayed with the message but not otherwise
+// consulted: per-page ordering is guaranteed instead by routing every
+// message for a given PageId to the same worker (see Queue.shardFor), so a
+// Delete can never be processed ahead of the Update that preceded it.
+type IndexerMetadata struct {
+	PageId  uint64
+	Op      Op
+	Version uint64
+}
+
+// FetchFunc re-fetches and parses the page named by pageId, turning
+// metadata into the models.Document that Engine.Index needs.
+type FetchFunc func(pageId uint64) (*models.Document, error)
+
+// Config selects the on-disk queue file, worker count, and channel size.
+type Config struct {
+	QueuePath  string
+	Workers    int
+	BufferSize int
+}
+
+// Queue accepts IndexerMetadata over a Bolt-backed set of per-worker
+// channels, so pending work survives a restart, and dispatches it to a
+// pool of workers calling Engine.Index or Engine.Delete. Every message for
+// a given PageId is routed to the same channel (see shardFor), so two
+// messages for the same page - an Update followed by a Delete, or a replay
+// racing a fresh crawl - are always processed in order by one worker
+// instead of racing across the pool.
+type Queue struct {
+	db      *bolt.DB
+	engine  retrieval.Engine
+	fetch   FetchFunc
+	shards  []chan queued
+	workers int
+	wg      sync.WaitGroup
+}
+
+type queued struct {
+	seq  uint64
+	meta IndexerMetadata
+}
+
+// New opens cfg.QueuePath, replays any work left pending from a previous
+// run, and starts cfg.Workers worker goroutines.
+func New(cfg Config, engine retrieval.Engine, fetch FetchFunc) (*Queue, error) {
+	db, err := bolt.Open(cfg.QueuePath, 0666, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(pendingBucket)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	bufferSize := cfg.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = 64
+	}
+
+	q := &Queue{
+		db:      db,
+		engine:  engine,
+		fetch:   fetch,
+		shards:  make([]chan queued, workers),
+		workers: workers,
+	}
+
+	for n := 0; n < workers; n++ {
+		ch := make(chan queued, bufferSize)
+		q.shards[n] = ch
+		q.wg.Add(1)
+		go q.work(ch)
+	}
+
+	q.replay()
+	return q, nil
+}
+
+// Enqueue persists meta to the pending bucket, then hands it to a worker.
+// Persisting first means a crash before the channel send still replays meta
+// on the next New.
+func (q *Queue) Enqueue(meta IndexerMetadata) error {
+	var seq uint64
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(pendingBucket)
+		next, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		seq = next
+
+		encoded, err := json.Marshal(meta)
+		if err != nil {
+			return err
+		}
+		return b.Put(seqKey(seq), encoded)
+	})
+	if err != nil {
+		return err
+	}
+
+	q.shardFor(meta.PageId) <- queued{seq: seq, meta: meta}
+	return nil
+}
+
+// replay re-queues every item still in the pending bucket from a previous
+// run that crashed or was stopped before its worker could ack it.
+func (q *Queue) replay() {
+	q.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(pendingBucket).ForEach(func(k, v []byte) error {
+			var meta IndexerMetadata
+			if err := json.Unmarshal(v, &meta); err != nil {
+				return nil
+			}
+			q.shardFor(meta.PageId) <- queued{seq: binary.BigEndian.Uint64(k), meta: meta}
+			return nil
+		})
+	})
+}
+
+// shardFor returns the channel every message for pageId must go through, so
+// ordering between messages for the same page is preserved regardless of
+// how many workers are running.
+func (q *Queue) shardFor(pageId uint64) chan queued {
+	return q.shards[pageId%uint64(len(q.shards))]
+}
+
+func (q *Queue) work(ch chan queued) {
+	defer q.wg.Done()
+	for item := range ch {
+		q.process(item)
+	}
+}
+
+// process runs item's Op against the engine and only acks it on success, so
+// an ordinary runtime error - a transient fetch failure, a bad Index/Delete
+// call - leaves the item in pendingBucket to be replayed on the next New
+// instead of being dropped silently. The error is logged either way, since a
+// replay-only retry can otherwise look like a healthy, empty queue.
+func (q *Queue) process(item queued) {
+	if err := q.dispatch(item.meta); err != nil {
+		log.Printf("indexqueue: page %d op %d: %v", item.meta.PageId, item.meta.Op, err)
+		return
+	}
+	q.ack(item.seq)
+}
+
+// dispatch performs the Op named by meta, returning any error from fetching
+// or from the engine so process can decide whether the item may be acked.
+func (q *Queue) dispatch(meta IndexerMetadata) error {
+	switch meta.Op {
+	case OpDelete:
+		return q.engine.Delete(meta.PageId)
+	case OpAdd, OpUpdate:
+		doc, err := q.fetch(meta.PageId)
+		if err != nil {
+			return err
+		}
+		if doc == nil {
+			return nil
+		}
+		return q.engine.Index(meta.PageId, doc)
+	}
+	return nil
+}
+
+// ack removes a processed item from the pending bucket.
+func (q *Queue) ack(seq uint64) {
+	q.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(pendingBucket).Delete(seqKey(seq))
+	})
+}
+
+// Status is a snapshot of queue depth, for the /admin/queue endpoint.
+type Status struct {
+	Pending int
+	Workers int
+}
+
+// Status reports how many items are still pending and how many workers are
+// draining them.
+func (q *Queue) Status() Status {
+	var pending int
+	q.db.View(func(tx *bolt.Tx) error {
+		pending = tx.Bucket(pendingBucket).Stats().KeyN
+		return nil
+	})
+	return Status{Pending: pending, Workers: q.workers}
+}
+
+// Close stops accepting new work and waits for in-flight items to finish.
+func (q *Queue) Close() error {
+	for _, ch := range q.shards {
+		close(ch)
+	}
+	q.wg.Wait()
+	return q.db.Close()
+}
+
+func seqKey(seq uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, seq)
+	return b
+}
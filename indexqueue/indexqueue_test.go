@@ -0,0 +1,136 @@
+package indexqueue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"comp4321/models"
+	"comp4321/retrieval"
+)
+
+// stubEngine records the order Index/Delete are called in, so tests can
+// assert on per-page processing order without a real database.Indexer.
+type stubEngine struct {
+	mu  sync.Mutex
+	ops []string
+}
+
+func (s *stubEngine) Index(pageId uint64, doc *models.Document) error {
+	s.mu.Lock()
+	s.ops = append(s.ops, "index")
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *stubEngine) Delete(pageId uint64) error {
+	s.mu.Lock()
+	s.ops = append(s.ops, "delete")
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *stubEngine) Search(ctx context.Context, opts retrieval.SearchOptions) (retrieval.SearchResponse, error) {
+	return retrieval.SearchResponse{}, nil
+}
+
+func (s *stubEngine) Close() error { return nil }
+
+// TestQueuePreservesPerPageOrder guards shardFor's ordering guarantee: an
+// Update immediately followed by a Delete for the same page must never be
+// processed Delete-before-Update, even with multiple workers running, since
+// two workers racing across the pool could otherwise reorder them.
+func TestQueuePreservesPerPageOrder(t *testing.T) {
+	f, err := os.CreateTemp("", "queue-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	engine := &stubEngine{}
+	fetch := func(pageId uint64) (*models.Document, error) {
+		return &models.Document{Uri: "http://example.com/a"}, nil
+	}
+
+	q, err := New(Config{QueuePath: f.Name(), Workers: 4}, engine, fetch)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	const pageId = 7
+	if err := q.Enqueue(IndexerMetadata{PageId: pageId, Op: OpAdd}); err != nil {
+		t.Fatalf("Enqueue add: %v", err)
+	}
+	if err := q.Enqueue(IndexerMetadata{PageId: pageId, Op: OpDelete}); err != nil {
+		t.Fatalf("Enqueue delete: %v", err)
+	}
+
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	want := []string{"index", "delete"}
+	if len(engine.ops) != len(want) {
+		t.Fatalf("ops = %v, want %v", engine.ops, want)
+	}
+	for i, op := range want {
+		if engine.ops[i] != op {
+			t.Fatalf("ops = %v, want %v", engine.ops, want)
+		}
+	}
+}
+
+// TestQueueDoesNotAckFailedFetch guards process's ack-on-success rule: a
+// transient fetch error must leave the item in pendingBucket instead of
+// being acked and dropped, so the next New's replay gets another chance at
+// it once the underlying failure clears.
+func TestQueueDoesNotAckFailedFetch(t *testing.T) {
+	f, err := os.CreateTemp("", "queue-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	failingEngine := &stubEngine{}
+	failFetch := func(pageId uint64) (*models.Document, error) {
+		return nil, errors.New("transient fetch failure")
+	}
+
+	q1, err := New(Config{QueuePath: f.Name(), Workers: 1}, failingEngine, failFetch)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := q1.Enqueue(IndexerMetadata{PageId: 1, Op: OpAdd}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if err := q1.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if len(failingEngine.ops) != 0 {
+		t.Fatalf("engine.ops = %v, want none - a failed fetch must never reach Index", failingEngine.ops)
+	}
+
+	// Reopening the same QueuePath replays whatever is still pending. If the
+	// failed fetch above had been acked anyway, this fetch (which succeeds)
+	// would never run and okEngine.ops would stay empty.
+	okEngine := &stubEngine{}
+	okFetch := func(pageId uint64) (*models.Document, error) {
+		return &models.Document{Uri: "http://example.com/a"}, nil
+	}
+	q2, err := New(Config{QueuePath: f.Name(), Workers: 1}, okEngine, okFetch)
+	if err != nil {
+		t.Fatalf("New (reopen): %v", err)
+	}
+	if err := q2.Close(); err != nil {
+		t.Fatalf("Close (reopen): %v", err)
+	}
+
+	if len(okEngine.ops) != 1 || okEngine.ops[0] != "index" {
+		t.Fatalf("okEngine.ops = %v, want [index] - the unacked item should replay", okEngine.ops)
+	}
+}
@@ -0,0 +1,200 @@
+package retrieval
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"comp4321/models"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/html"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// bleveEngine is an Engine implementation backed by a bleve index, kept on
+// disk alongside index.db so operators can try it without touching the
+// crawler or handlers.
+type bleveEngine struct {
+	index bleve.Index
+}
+
+func newBleveEngine(path string) (Engine, error) {
+	index, err := bleve.Open(path)
+	if err == bleve.ErrorIndexPathDoesNotExist {
+		index, err = bleve.New(path, bleveMapping())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bleveEngine{index: index}, nil
+}
+
+// bleveMapping gives every field the lowercase name buildQuery, Search's
+// sort/facet, and the host conjunction assume ("title", "host", "url",
+// "pagerank", "date"). Without it, bleve's default reflection mapping would
+// index bleveDoc's fields under their literal Go names, and every one of
+// those lowercase queries would silently match or sort nothing.
+func bleveMapping() *mapping.IndexMappingImpl {
+	idx := bleve.NewIndexMapping()
+	doc := bleve.NewDocumentMapping()
+	doc.AddFieldMappingsAt("title", bleve.NewTextFieldMapping())
+	doc.AddFieldMappingsAt("body", bleve.NewTextFieldMapping())
+	doc.AddFieldMappingsAt("host", bleve.NewTextFieldMapping())
+	doc.AddFieldMappingsAt("url", bleve.NewTextFieldMapping())
+	doc.AddFieldMappingsAt("pagerank", bleve.NewNumericFieldMapping())
+	doc.AddFieldMappingsAt("date", bleve.NewDateTimeFieldMapping())
+	idx.AddDocumentMapping("_default", doc)
+	return idx
+}
+
+// bleveDoc is the flat shape actually handed to bleve.Index, with the
+// lowercase field names bleveMapping declares - indexing *models.Document
+// directly would index its fields under their literal Go names instead.
+type bleveDoc struct {
+	Title    string    `json:"title"`
+	Body     string    `json:"body"`
+	Host     string    `json:"host"`
+	Url      string    `json:"url"`
+	PageRank float64   `json:"pagerank"`
+	Date     time.Time `json:"date"`
+}
+
+// Index keys the bleve document by pageId rather than doc.Uri, so Delete -
+// which only gets pageId, the id database.Indexer natively keys by - can
+// address the same document without a separate id lookup.
+//
+// PageRank is left at its zero value: bleve has no equivalent of
+// Indexer.UpdatePageRank's link-graph pass, so SortByPageRank against this
+// backend always sorts by 0 until something feeds it a real score. Date is
+// stamped at index time, since models.Document carries no crawl timestamp
+// of its own for this backend to index instead.
+func (e *bleveEngine) Index(pageId uint64, doc *models.Document) error {
+	return e.index.Index(bleveDocId(pageId), bleveDoc{
+		Title: doc.Title,
+		Body:  doc.Body,
+		Host:  doc.Host,
+		Url:   doc.Uri,
+		Date:  time.Now(),
+	})
+}
+
+func (e *bleveEngine) Delete(pageId uint64) error {
+	return e.index.Delete(bleveDocId(pageId))
+}
+
+func bleveDocId(pageId uint64) string {
+	return strconv.FormatUint(pageId, 10)
+}
+
+// bleveStoredFields are always requested alongside any caller-specified
+// opts.Fields: Search needs them back on every hit to populate Hit.Doc.
+var bleveStoredFields = []string{"title", "url", "host"}
+
+func (e *bleveEngine) Search(ctx context.Context, opts SearchOptions) (SearchResponse, error) {
+	q := buildQuery(opts)
+	size := opts.Paging.Limit
+	if size <= 0 {
+		// As in boltEngine, a zero Limit returns every hit rather than none.
+		count, err := e.index.DocCount()
+		if err != nil {
+			return SearchResponse{}, err
+		}
+		size = int(count)
+	}
+	bq := bleve.NewSearchRequestOptions(q, size, opts.Paging.Offset, false)
+	bq.Fields = append(append([]string{}, opts.Fields...), bleveStoredFields...)
+	bq.Facet("host", bleve.NewFacetRequest("host", 10))
+
+	switch opts.SortBy {
+	case SortByDate:
+		bq.SortBy([]string{"-date"})
+	case SortByPageRank:
+		bq.SortBy([]string{"-pagerank"})
+	}
+
+	if opts.Highlight {
+		bq.Highlight = bleve.NewHighlightWithStyle(html.Name)
+	}
+
+	result, err := e.index.Search(bq)
+	if err != nil {
+		return SearchResponse{}, err
+	}
+
+	hits := make([]Hit, 0, len(result.Hits))
+	for _, h := range result.Hits {
+		pageId, err := strconv.ParseUint(h.ID, 10, 64)
+		if err != nil {
+			// h.ID is always whatever bleveDocId produced, so this only
+			// trips if the index was populated by something else.
+			continue
+		}
+
+		hit := Hit{
+			Score: h.Score,
+			Doc: &models.DocumentView{
+				PageId: pageId,
+				Title:  bleveStringField(h.Fields, "title"),
+				Url:    bleveStringField(h.Fields, "url"),
+				Host:   bleveStringField(h.Fields, "host"),
+			},
+		}
+		for field, fragments := range h.Fragments {
+			hit.Highlights = append(hit.Highlights, Fragment{Field: field, Fragments: fragments})
+		}
+		hits = append(hits, hit)
+	}
+
+	facets := Facets{ByHost: make(map[string]int)}
+	if hostFacet, ok := result.Facets["host"]; ok {
+		for _, term := range hostFacet.Terms.Terms() {
+			facets.ByHost[term.Term] = term.Count
+		}
+	}
+
+	return SearchResponse{TotalHits: int(result.Total), Hits: hits, Facets: facets}, nil
+}
+
+// bleveStringField reads a stored field back off a hit, returning "" if it
+// wasn't requested or wasn't a string.
+func bleveStringField(fields map[string]interface{}, name string) string {
+	s, _ := fields[name].(string)
+	return s
+}
+
+// buildQuery translates SearchOptions into the bleve query tree: a phrase
+// query when PhraseTerms is set, a query string otherwise, restricted to the
+// title field when TitleOnly is set and to SiteFilter's host when present.
+func buildQuery(opts SearchOptions) query.Query {
+	var q query.Query
+	if len(opts.PhraseTerms) > 0 {
+		mq := bleve.NewMatchPhraseQuery(strings.Join(opts.PhraseTerms, " "))
+		if opts.TitleOnly {
+			mq.SetField("title")
+		}
+		q = mq
+	} else if opts.TitleOnly {
+		mq := bleve.NewMatchQuery(opts.Keyword)
+		mq.SetField("title")
+		q = mq
+	} else {
+		q = bleve.NewQueryStringQuery(opts.Keyword)
+	}
+
+	if opts.SiteFilter == "" {
+		return q
+	}
+
+	hostQuery := bleve.NewMatchQuery(opts.SiteFilter)
+	hostQuery.SetField("host")
+
+	conjunction := bleve.NewConjunctionQuery(q, hostQuery)
+	return conjunction
+}
+
+func (e *bleveEngine) Close() error {
+	return e.index.Close()
+}
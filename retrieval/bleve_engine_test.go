@@ -0,0 +1,96 @@
+package retrieval
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"comp4321/models"
+)
+
+// TestBleveEngineSearchPopulatesDoc guards the lowercase field mapping and
+// Hit.Doc population bleveMapping/Search rely on: without an explicit
+// mapping, bleve indexes models.Document's fields under their literal Go
+// names ("Title", "Host") and the lowercase queries/facets here match
+// nothing; without PageId/Doc set on each Hit, every caller that
+// dereferences hit.Doc (cmd/search.go) would nil-panic.
+func TestBleveEngineSearchPopulatesDoc(t *testing.T) {
+	dir, err := os.MkdirTemp("", "bleve-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	engine, err := NewEngine(Config{Backend: BackendBleve, DBPath: dir + "/index.bleve"})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	defer engine.Close()
+
+	doc := &models.Document{
+		Uri:   "http://example.com/a",
+		Title: "hello world",
+		Body:  "hello world body text",
+		Host:  "example.com",
+	}
+	if err := engine.Index(1, doc); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+
+	resp, err := engine.Search(context.Background(), SearchOptions{Keyword: "hello", SiteFilter: "example.com"})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if resp.TotalHits != 1 {
+		t.Fatalf("TotalHits = %d, want 1", resp.TotalHits)
+	}
+
+	hit := resp.Hits[0]
+	if hit.Doc == nil {
+		t.Fatal("Hit.Doc = nil, want a populated DocumentView")
+	}
+	if hit.Doc.PageId != 1 {
+		t.Errorf("Hit.Doc.PageId = %d, want 1", hit.Doc.PageId)
+	}
+	if hit.Doc.Title != doc.Title {
+		t.Errorf("Hit.Doc.Title = %q, want %q", hit.Doc.Title, doc.Title)
+	}
+	if hit.Doc.Host != doc.Host {
+		t.Errorf("Hit.Doc.Host = %q, want %q", hit.Doc.Host, doc.Host)
+	}
+}
+
+// TestBleveEngineTitleOnlyKeyword guards the keyword (non-phrase) branch of
+// buildQuery: TitleOnly used to only be honored when PhraseTerms was set, so
+// a plain keyword search with TitleOnly never restricted to the title field.
+func TestBleveEngineTitleOnlyKeyword(t *testing.T) {
+	dir, err := os.MkdirTemp("", "bleve-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	engine, err := NewEngine(Config{Backend: BackendBleve, DBPath: dir + "/index.bleve"})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	defer engine.Close()
+
+	if err := engine.Index(1, &models.Document{Uri: "http://example.com/a", Title: "hello", Body: "unrelated"}); err != nil {
+		t.Fatalf("Index a: %v", err)
+	}
+	if err := engine.Index(2, &models.Document{Uri: "http://example.com/b", Title: "unrelated", Body: "hello"}); err != nil {
+		t.Fatalf("Index b: %v", err)
+	}
+
+	resp, err := engine.Search(context.Background(), SearchOptions{Keyword: "hello", TitleOnly: true})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if resp.TotalHits != 1 {
+		t.Fatalf("TotalHits = %d, want 1 (only the doc with \"hello\" in its title)", resp.TotalHits)
+	}
+	if resp.Hits[0].Doc.PageId != 1 {
+		t.Errorf("matched PageId = %d, want 1", resp.Hits[0].Doc.PageId)
+	}
+}
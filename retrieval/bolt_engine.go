@@ -0,0 +1,171 @@
+package retrieval
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"comp4321/database"
+	"comp4321/models"
+)
+
+// boltEngine is the original Engine implementation: queries run against a
+// point-in-time database.IndexReader taken from a long-lived Indexer, rather
+// than opening a fresh database.Viewer per request.
+type boltEngine struct {
+	indexer *database.Indexer
+}
+
+func newBoltEngine(path string) (Engine, error) {
+	indexer, err := database.LoadIndexer(path)
+	if err != nil {
+		return nil, err
+	}
+	return &boltEngine{indexer: indexer}, nil
+}
+
+// Index upserts doc into the BoltDB index. pageId is not needed here -
+// database.Indexer derives it itself from doc.Uri - but Engine's signature
+// passes it to every backend uniformly so Bleve can key its index by it too.
+//
+// UpdateOrAddPage only buffers the new postings in the Indexer's in-memory
+// tempInverted map; FlushInverted must run before they land in the on-disk
+// inverted buckets that searchPhrase/booleanFilter read through
+// IndexReader.TermFieldReader. Flushing per call keeps every Index call
+// immediately searchable, which matters most for the indexqueue workers
+// that are the only callers of this path.
+func (e *boltEngine) Index(pageId uint64, doc *models.Document) error {
+	e.indexer.UpdateOrAddPage(doc)
+	e.indexer.FlushInverted()
+	return nil
+}
+
+// Delete removes pageId from every field's forward and inverted index.
+func (e *boltEngine) Delete(pageId uint64) error {
+	e.indexer.Delete(pageId)
+	return nil
+}
+
+func (e *boltEngine) Search(ctx context.Context, opts SearchOptions) (SearchResponse, error) {
+	reader, err := e.indexer.Reader()
+	if err != nil {
+		return SearchResponse{}, err
+	}
+	defer reader.Close()
+
+	query, field := opts.PhraseTerms, database.FieldBody
+	if len(query) == 0 {
+		query, field = ParseQuery(opts.Keyword)
+	}
+	if opts.TitleOnly {
+		field = database.FieldTitle
+	}
+	docIds := searchPhrase(query, reader, field)
+
+	alpha := opts.Alpha
+	if alpha == 0 {
+		alpha = DefaultBlendAlpha
+	}
+
+	docs := make([]*models.DocumentView, 0, len(docIds))
+	for _, id := range docIds {
+		doc := reader.GetDocumentView(id)
+		if doc == nil {
+			// PageInfo is missing or failed to unmarshal - plausible since
+			// UpdateOrAddPage writes forward/inverted postings and PageInfo
+			// in separate, non-atomic transactions, so a crash in between
+			// can leave a docId with postings but no PageInfo record. Skip
+			// the hit instead of panicking the whole search.
+			continue
+		}
+		if !e.passesFilters(doc, opts) {
+			continue
+		}
+		doc.Score = blendScore(cosineScore(reader, id, query), doc.PageRank, alpha)
+		docs = append(docs, doc)
+	}
+
+	facets := computeFacets(reader, docs)
+	sortHits(docs, opts.SortBy)
+
+	from, size := opts.Paging.Offset, opts.Paging.Limit
+	if size <= 0 {
+		size = len(docs)
+	}
+
+	hits := make([]Hit, 0, size)
+	for i, doc := range docs {
+		if i < from {
+			continue
+		}
+		if len(hits) >= size {
+			break
+		}
+
+		hit := Hit{Doc: doc, Score: doc.Score}
+		if opts.Highlight {
+			hit.Highlights = highlightFragments(reader, doc.PageId, query)
+		}
+		hits = append(hits, hit)
+	}
+
+	return SearchResponse{TotalHits: len(docs), Hits: hits, Facets: facets}, nil
+}
+
+// passesFilters applies SiteFilter and the date range to a candidate before
+// it counts toward TotalHits or facets. TitleOnly is not checked here: Search
+// applies it earlier by restricting searchPhrase to FieldTitle.
+func (e *boltEngine) passesFilters(doc *models.DocumentView, opts SearchOptions) bool {
+	if opts.SiteFilter != "" && !strings.Contains(doc.Host, opts.SiteFilter) {
+		return false
+	}
+	if !opts.DateFrom.IsZero() && doc.Date.Before(opts.DateFrom) {
+		return false
+	}
+	if !opts.DateTo.IsZero() && doc.Date.After(opts.DateTo) {
+		return false
+	}
+	return true
+}
+
+// topTermsLimit bounds how many TopTerms facets computeFacets returns.
+const topTermsLimit = 10
+
+// computeFacets summarizes the full filtered candidate set: per-host counts,
+// and the top terms actually occurring in those documents' body text
+// (computed from their forward-index postings, not the query itself) so
+// the UI can offer faceted navigation alongside the current page of Hits.
+func computeFacets(reader *database.IndexReader, docs []*models.DocumentView) Facets {
+	byHost := make(map[string]int)
+	pageIds := make([]uint64, 0, len(docs))
+	for _, doc := range docs {
+		byHost[doc.Host]++
+		pageIds = append(pageIds, doc.PageId)
+	}
+
+	termFreqs := reader.TopTermsForField(pageIds, database.FieldBody, topTermsLimit)
+	topTerms := make([]TermCount, 0, len(termFreqs))
+	for _, tf := range termFreqs {
+		topTerms = append(topTerms, TermCount{Term: tf.Term, Count: tf.Count})
+	}
+
+	return Facets{ByHost: byHost, TopTerms: topTerms}
+}
+
+// sortHits orders docs in place per SortBy; the zero value (and
+// SortByRelevance) sorts by the blended cosine/PageRank Score.
+func sortHits(docs []*models.DocumentView, by SortBy) {
+	switch by {
+	case SortByDate:
+		sort.SliceStable(docs, func(i, j int) bool { return docs[i].Date.After(docs[j].Date) })
+	case SortByPageRank:
+		sort.SliceStable(docs, func(i, j int) bool { return docs[i].PageRank > docs[j].PageRank })
+	default:
+		sort.SliceStable(docs, func(i, j int) bool { return docs[i].Score > docs[j].Score })
+	}
+}
+
+func (e *boltEngine) Close() error {
+	e.indexer.Close()
+	return nil
+}
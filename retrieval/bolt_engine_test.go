@@ -0,0 +1,46 @@
+package retrieval
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"comp4321/models"
+)
+
+// TestBoltEngineIndexIsSearchable guards against Engine.Index leaving a
+// document unreachable: UpdateOrAddPage only buffers postings in memory
+// until FlushInverted runs, and the indexqueue workers (the only callers of
+// Index outside this test) have no other way to trigger a flush.
+func TestBoltEngineIndexIsSearchable(t *testing.T) {
+	f, err := os.CreateTemp("", "index-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	engine, err := NewEngine(Config{Backend: BackendBolt, DBPath: f.Name()})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	defer engine.Close()
+
+	doc := &models.Document{
+		Uri:   "http://example.com/a",
+		Title: "hello world",
+		Words: map[string]int{"hello": 1, "world": 1},
+		MaxTf: 1,
+	}
+	if err := engine.Index(1, doc); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+
+	resp, err := engine.Search(context.Background(), SearchOptions{Keyword: "hello"})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if resp.TotalHits != 1 {
+		t.Fatalf("TotalHits = %d, want 1 after indexing via Engine.Index", resp.TotalHits)
+	}
+}
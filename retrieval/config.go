@@ -0,0 +1,22 @@
+package retrieval
+
+import "fmt"
+
+// Config selects and configures a search Engine at startup.
+type Config struct {
+	Backend Backend
+	DBPath  string
+}
+
+// NewEngine constructs the Engine selected by cfg, defaulting to the BoltDB
+// backend when Backend is unset.
+func NewEngine(cfg Config) (Engine, error) {
+	switch cfg.Backend {
+	case "", BackendBolt:
+		return newBoltEngine(cfg.DBPath)
+	case BackendBleve:
+		return newBleveEngine(cfg.DBPath)
+	default:
+		return nil, fmt.Errorf("retrieval: unknown backend %q", cfg.Backend)
+	}
+}
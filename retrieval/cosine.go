@@ -0,0 +1,61 @@
+package retrieval
+
+import (
+	"math"
+
+	"comp4321/database"
+)
+
+// fieldBoost weighs each field's cosine contribution to the final score
+// before the per-field scores are summed in cosineScore: a term matched in
+// the title counts for five times as much as the same term only appearing
+// in the body.
+var fieldBoost = map[string]float64{
+	database.FieldTitle.Name:  5,
+	database.FieldAnchor.Name: 3,
+	database.FieldUrl.Name:    2,
+	database.FieldBody.Name:   1,
+}
+
+// cosineScore sums one boost-weighted cosine per field, reusing the
+// per-field tf-idf vector norms Indexer.UpdateTermWeights precomputed.
+func cosineScore(reader *database.IndexReader, pageId uint64, query []string) float64 {
+	var total float64
+	for _, field := range database.Fields {
+		total += fieldBoost[field.Name] * fieldCosine(reader, pageId, query, field)
+	}
+	return total
+}
+
+func fieldCosine(reader *database.IndexReader, pageId uint64, query []string, field database.Field) float64 {
+	// maxTf must be the document's true per-field maximum - the same basis
+	// Indexer.updateTermWeightsForField used to build docNorm below - not
+	// the max among only the query's terms. Using a query-scoped maxTf here
+	// put the numerator and denominator on different scales and could
+	// inflate a single matching term as if it were the document's most
+	// frequent word.
+	maxTf := reader.GetMaxTf(pageId, field)
+	if maxTf == 0 {
+		return 0
+	}
+
+	tfs := make(map[string]int, len(query))
+	for _, term := range query {
+		tfs[term] = reader.GetTermFrequency(pageId, term, field)
+	}
+
+	var dot, queryNormSq float64
+	for _, term := range query {
+		idf := reader.GetIdf(term, field)
+		docWeight := (float64(tfs[term]) / float64(maxTf)) * idf
+		dot += docWeight * idf
+		queryNormSq += idf * idf
+	}
+
+	docNorm := reader.GetTermWeightNorm(pageId, field)
+	queryNorm := math.Sqrt(queryNormSq)
+	if docNorm == 0 || queryNorm == 0 {
+		return 0
+	}
+	return dot / (docNorm * queryNorm)
+}
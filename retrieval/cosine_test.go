@@ -0,0 +1,73 @@
+package retrieval
+
+import (
+	"math"
+	"os"
+	"testing"
+
+	"comp4321/database"
+	"comp4321/models"
+)
+
+// TestFieldCosineUsesDocumentMaxTf guards against fieldCosine computing its
+// own maxTf from only the query's terms: that basis disagrees with the
+// document's true per-field maxTf baked into the stored docNorm
+// (Indexer.UpdateTermWeights), and can wildly inflate scores for documents
+// where a matched term isn't actually the document's most frequent word.
+func TestFieldCosineUsesDocumentMaxTf(t *testing.T) {
+	f, err := os.CreateTemp("", "index-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	indexer, err := database.LoadIndexer(f.Name())
+	if err != nil {
+		t.Fatalf("LoadIndexer: %v", err)
+	}
+	defer indexer.Close()
+
+	// doc1's true maxTf is 5 ("common"), but the query only ever asks about
+	// "special" (tf=1) - the bug computed maxTf=1 from that alone.
+	indexer.UpdateOrAddPage(&models.Document{
+		Uri:   "http://example.com/a",
+		Words: map[string]int{"common": 5, "special": 1},
+		MaxTf: 5,
+	})
+	indexer.UpdateOrAddPage(&models.Document{
+		Uri:   "http://example.com/b",
+		Words: map[string]int{"common": 3},
+		MaxTf: 3,
+	})
+	indexer.FlushInverted()
+	indexer.UpdateTermWeights()
+
+	reader, err := indexer.Reader()
+	if err != nil {
+		t.Fatalf("Reader: %v", err)
+	}
+	defer reader.Close()
+
+	var pageId uint64
+	docs := reader.DocIDReader(0, math.MaxUint64)
+	for id, ok := docs.Next(); ok; id, ok = docs.Next() {
+		doc, _ := reader.GetDocument(id)
+		if doc != nil && doc.Uri == "http://example.com/a" {
+			pageId = id
+		}
+	}
+	if pageId == 0 {
+		t.Fatal("doc a not found")
+	}
+
+	got := fieldCosine(reader, pageId, []string{"special"}, database.FieldBody)
+	// idf(special) = log2(2/1) = 1; docWeight = (1/5)*1 = 0.2; dot = 0.2;
+	// docNorm = sqrt((5/5*log2(2/2))^2 + (1/5*log2(2/1))^2) = 0.2;
+	// queryNorm = 1 -> cosine = 0.2/(0.2*1) = 1.0. The bug's query-scoped
+	// maxTf=1 instead produces dot=1, cosine=5.0.
+	want := 1.0
+	if math.Abs(got-want) > 1e-9 {
+		t.Fatalf("fieldCosine = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,102 @@
+package retrieval
+
+import (
+	"context"
+	"time"
+
+	"comp4321/models"
+)
+
+// Backend names one of the search implementations that can satisfy Engine.
+type Backend string
+
+const (
+	BackendBolt  Backend = "bolt"
+	BackendBleve Backend = "bleve"
+)
+
+// SortBy selects the ordering applied to a SearchOptions result.
+type SortBy string
+
+const (
+	SortByRelevance SortBy = "relevance"
+	SortByDate      SortBy = "date"
+	SortByPageRank  SortBy = "pagerank"
+)
+
+// Paging is an offset/limit window over a SearchOptions result. Offset and
+// Limit are applied by the engine itself, after filtering, so
+// SearchResponse.TotalHits always
+// reflects the filtered set rather than the unfiltered candidate list.
+type Paging struct {
+	Offset int
+	Limit  int
+}
+
+// SearchOptions is the backend-independent description of a query: the
+// keyword/phrase to match, the filters to narrow candidates, how to sort,
+// and the page of results to return.
+type SearchOptions struct {
+	Keyword     string
+	PhraseTerms []string
+	Fields      []string
+	SiteFilter  string
+	TitleOnly   bool
+	DateFrom    time.Time
+	DateTo      time.Time
+	SortBy      SortBy
+	Paging      Paging
+	Highlight   bool
+
+	// Alpha weighs the blended score: alpha*cosine + (1-alpha)*pageRank.
+	// Zero means DefaultBlendAlpha.
+	Alpha float64
+}
+
+// Fragment holds the highlighted snippets found for one field of one hit,
+// mirroring bleve's per-field Fragments map.
+type Fragment struct {
+	Field     string
+	Fragments []string
+}
+
+// Hit is a single search result, with optional highlighted snippets attached
+// so ResultView can render them without talking to the backend directly.
+type Hit struct {
+	Doc        *models.DocumentView
+	Score      float64
+	Highlights []Fragment
+}
+
+// TermCount is one entry in a Facets.TopTerms list.
+type TermCount struct {
+	Term  string
+	Count int
+}
+
+// Facets summarizes the full filtered result set so the UI can offer
+// faceted navigation alongside the current page of Hits.
+type Facets struct {
+	ByHost   map[string]int
+	TopTerms []TermCount
+}
+
+// SearchResponse is the backend-independent result of a Search call.
+// TotalHits reflects the filtered candidate set, not just len(Hits).
+type SearchResponse struct {
+	TotalHits int
+	Hits      []Hit
+	Facets    Facets
+}
+
+// Engine is implemented by every search backend (BoltDB, Bleve, ...) so the
+// crawler and handlers can be written against one interface and the backend
+// can be swapped through configuration alone. pageId is passed into Index
+// alongside doc, rather than left for the backend to derive from doc.Uri,
+// so every backend can key its index by the same id Delete(pageId) expects.
+type Engine interface {
+	Index(pageId uint64, doc *models.Document) error
+	Search(ctx context.Context, opts SearchOptions) (SearchResponse, error)
+	Delete(pageId uint64) error
+	Close() error
+}
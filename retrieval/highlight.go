@@ -0,0 +1,74 @@
+package retrieval
+
+import (
+	"comp4321/database"
+	"strings"
+)
+
+// snippetWindow is the number of words kept on each side of a matched term.
+const snippetWindow = 5
+
+// highlightFragments builds a short snippet around each query term found in
+// the document, similar to bleve's Fragments highlighter.
+func highlightFragments(reader *database.IndexReader, pageId uint64, query []string) []Fragment {
+	doc, err := reader.GetDocument(pageId)
+	if err != nil || doc == nil {
+		return nil
+	}
+
+	fragments := make([]Fragment, 0, 2)
+	if f := fragmentsForField("title", doc.Title, query); f != nil {
+		fragments = append(fragments, *f)
+	}
+	if f := fragmentsForField("body", doc.Body, query); f != nil {
+		fragments = append(fragments, *f)
+	}
+	return fragments
+}
+
+// fragmentsForField scans text for each term in query and returns one
+// <mark>-wrapped snippet per matched term, centered on that term's first
+// occurrence, or nil if none match.
+func fragmentsForField(field, text string, query []string) *Fragment {
+	words := strings.Fields(text)
+	lower := make([]string, len(words))
+	for i, w := range words {
+		lower[i] = strings.ToLower(w)
+	}
+
+	var snippets []string
+	for _, term := range query {
+		idx := indexOf(lower, term)
+		if idx < 0 {
+			continue
+		}
+
+		start := idx - snippetWindow
+		if start < 0 {
+			start = 0
+		}
+		end := idx + snippetWindow + 1
+		if end > len(words) {
+			end = len(words)
+		}
+
+		snippet := append([]string{}, words[start:idx]...)
+		snippet = append(snippet, "<mark>"+words[idx]+"</mark>")
+		snippet = append(snippet, words[idx+1:end]...)
+		snippets = append(snippets, strings.Join(snippet, " "))
+	}
+
+	if len(snippets) == 0 {
+		return nil
+	}
+	return &Fragment{Field: field, Fragments: snippets}
+}
+
+func indexOf(words []string, term string) int {
+	for i, w := range words {
+		if w == term {
+			return i
+		}
+	}
+	return -1
+}
@@ -18,14 +18,15 @@ func splitToBigrams(query []string) (bigrams []Bigram) {
 	return
 }
 
-// Returns docIds that contain the bigram phrase.
-func hasPhrase(bigram Bigram, viewer *database.Viewer) []uint64{
-	docIds := booleanFilter([]string{bigram.n1, bigram.n2}, viewer)
+// Returns docIds that contain the bigram phrase. Position indices only
+// exist for FieldBody, so phrase adjacency is only meaningful there.
+func hasPhrase(bigram Bigram, reader *database.IndexReader) []uint64{
+	docIds := booleanFilter([]string{bigram.n1, bigram.n2}, reader, database.FieldBody)
 	rv := make([]uint64, 0)
 
 	for _, id := range docIds {
-		pos1 := viewer.GetPositionIndices(id, bigram.n1)
-		pos2 := viewer.GetPositionIndices(id, bigram.n2)
+		pos1 := reader.GetPositionIndices(id, bigram.n1)
+		pos2 := reader.GetPositionIndices(id, bigram.n2)
 
 		for i, _ := range pos2 {
 			pos2[i]--
@@ -40,18 +41,22 @@ func hasPhrase(bigram Bigram, viewer *database.Viewer) []uint64{
 	return rv
 }
 
-// Treat the query as a phrase and returns docIds containing the phrase.
-// Changes the query into bigrams and find documents containing all bigrams.
-func searchPhrase(query []string, viewer *database.Viewer) []uint64{
-	if len(query) <= 1 {
-		return booleanFilter(query, viewer)
+// Treat the query as a phrase and returns docIds containing the phrase,
+// restricted to field. Changes the query into bigrams and finds documents
+// containing all bigrams. field-restricted queries other than FieldBody
+// have no position index to confirm adjacency, so they fall back to a
+// boolean AND of the terms within that field (e.g. title:"hong kong" only
+// requires both words to appear somewhere in the title).
+func searchPhrase(query []string, reader *database.IndexReader, field database.Field) []uint64{
+	if len(query) <= 1 || field != database.FieldBody {
+		return booleanFilter(query, reader, field)
 	}
 
 	bigrams := splitToBigrams(query)
 	docWithBigrams := make([][]uint64, 0)
 
 	for _, bigram := range bigrams {
-		docWithBigrams = append(docWithBigrams, hasPhrase(bigram, viewer))
+		docWithBigrams = append(docWithBigrams, hasPhrase(bigram, reader))
 	}
 
 	sort.Slice(docWithBigrams, func(i, j int) bool {
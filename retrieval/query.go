@@ -0,0 +1,32 @@
+package retrieval
+
+import (
+	"strings"
+
+	"comp4321/database"
+)
+
+// fieldPrefixes are the field-restricted query prefixes ParseQuery
+// recognizes, e.g. `title:"hong kong"`. Body has no prefix since it is the
+// default field.
+var fieldPrefixes = []database.Field{database.FieldTitle, database.FieldAnchor, database.FieldUrl}
+
+// ParseQuery splits a raw query into lowercased terms and the field it
+// should be restricted to. A leading `field:` prefix (optionally followed
+// by a quoted phrase) restricts the search to that field; otherwise the
+// query runs against FieldBody.
+func ParseQuery(raw string) ([]string, database.Field) {
+	trimmed := strings.TrimSpace(raw)
+
+	for _, field := range fieldPrefixes {
+		prefix := field.Name + ":"
+		if !strings.HasPrefix(trimmed, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(trimmed, prefix)
+		rest = strings.Trim(rest, `"`)
+		return strings.Fields(strings.ToLower(rest)), field
+	}
+
+	return strings.Fields(strings.ToLower(trimmed)), database.FieldBody
+}
@@ -0,0 +1,11 @@
+package retrieval
+
+// DefaultBlendAlpha weighs cosine similarity against PageRank when
+// SearchOptions.Alpha is left at its zero value.
+const DefaultBlendAlpha = 0.85
+
+// blendScore combines a query-dependent cosine score with the query-
+// independent PageRank score: alpha*cosine + (1-alpha)*pageRank.
+func blendScore(cosine, pageRank, alpha float64) float64 {
+	return alpha*cosine + (1-alpha)*pageRank
+}